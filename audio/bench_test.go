@@ -0,0 +1,119 @@
+package audio
+
+import "testing"
+
+const (
+	benchChannels  = 2
+	benchBlockSize = 256
+)
+
+func benchGraph32(b *testing.B, build func(g *Graph)) {
+	g := NewGraph(48000, benchChannels, benchBlockSize)
+	build(g)
+	if err := g.Build(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := g.Process32(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchGraph64(b *testing.B, build func(g *Graph)) {
+	g := NewGraph(48000, benchChannels, benchBlockSize)
+	build(g)
+	if err := g.Build(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := g.Process64(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func addSilence(g *Graph, name string) {
+	g.Add(name, nil, func(in32 []*Buffer32, in64 []*Buffer64) Node { return silence{} })
+}
+
+type silence struct{}
+
+func (silence) Process32(out *Buffer32) error { return nil }
+func (silence) Process64(out *Buffer64) error { return nil }
+
+func BenchmarkGain32(b *testing.B) {
+	benchGraph32(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("gain", []string{"src"}, NewGain(0.5))
+	})
+}
+
+func BenchmarkGain64(b *testing.B) {
+	benchGraph64(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("gain", []string{"src"}, NewGain(0.5))
+	})
+}
+
+func BenchmarkMixer32(b *testing.B) {
+	benchGraph32(b, func(g *Graph) {
+		addSilence(g, "a")
+		addSilence(g, "b")
+		g.Add("mix", []string{"a", "b"}, NewMixer(0.5, 0.5))
+	})
+}
+
+func BenchmarkMixer64(b *testing.B) {
+	benchGraph64(b, func(g *Graph) {
+		addSilence(g, "a")
+		addSilence(g, "b")
+		g.Add("mix", []string{"a", "b"}, NewMixer(0.5, 0.5))
+	})
+}
+
+func BenchmarkBiquad32(b *testing.B) {
+	benchGraph32(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("lpf", []string{"src"}, NewLowpassBiquad(48000, 2000, 0.707))
+	})
+}
+
+func BenchmarkBiquad64(b *testing.B) {
+	benchGraph64(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("lpf", []string{"src"}, NewLowpassBiquad(48000, 2000, 0.707))
+	})
+}
+
+func BenchmarkDelay32(b *testing.B) {
+	benchGraph32(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("delay", []string{"src"}, NewDelay(1000))
+	})
+}
+
+func BenchmarkDelay64(b *testing.B) {
+	benchGraph64(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("delay", []string{"src"}, NewDelay(1000))
+	})
+}
+
+func BenchmarkResampler32(b *testing.B) {
+	benchGraph32(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("resample", []string{"src"}, NewResampler(44100.0/48000.0))
+	})
+}
+
+func BenchmarkResampler64(b *testing.B) {
+	benchGraph64(b, func(g *Graph) {
+		addSilence(g, "src")
+		g.Add("resample", []string{"src"}, NewResampler(44100.0/48000.0))
+	})
+}
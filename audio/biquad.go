@@ -0,0 +1,86 @@
+package audio
+
+import "math"
+
+// Biquad is a direct-form-I IIR biquad filter:
+//
+//	y[n] = b0*x[n] + b1*x[n-1] + b2*x[n-2] - a1*y[n-1] - a2*y[n-2]
+//
+// Construct one with NewBiquad for raw coefficients, or NewLowpassBiquad
+// for a ready-made lowpass.
+type Biquad struct {
+	in32 *Buffer32
+	in64 *Buffer64
+
+	B0, B1, B2 float64
+	A1, A2     float64
+
+	state32 []biquadState32 // per channel
+	state64 []biquadState64
+}
+
+type biquadState32 struct{ x1, x2, y1, y2 float32 }
+type biquadState64 struct{ x1, x2, y1, y2 float64 }
+
+// NewBiquad returns a NodeFactory for a Biquad with explicit,
+// already-normalized (a0 == 1) coefficients.
+func NewBiquad(b0, b1, b2, a1, a2 float64) NodeFactory {
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node {
+		return &Biquad{in32: in32[0], in64: in64[0], B0: b0, B1: b1, B2: b2, A1: a1, A2: a2}
+	}
+}
+
+// NewLowpassBiquad builds the RBJ cookbook lowpass biquad for cutoff Hz
+// at sampleRate Hz, with quality q (0.707 gives no resonance peak).
+func NewLowpassBiquad(sampleRate, cutoff, q float64) NodeFactory {
+	w0 := 2 * math.Pi * cutoff / sampleRate
+	cosW0, sinW0 := math.Cos(w0), math.Sin(w0)
+	alpha := sinW0 / (2 * q)
+
+	b0 := (1 - cosW0) / 2
+	b1 := 1 - cosW0
+	b2 := (1 - cosW0) / 2
+	a0 := 1 + alpha
+	a1 := -2 * cosW0
+	a2 := 1 - alpha
+
+	return NewBiquad(b0/a0, b1/a0, b2/a0, a1/a0, a2/a0)
+}
+
+func (f *Biquad) Process32(out *Buffer32) error {
+	if f.state32 == nil {
+		f.state32 = make([]biquadState32, out.Channels)
+	}
+	b0, b1, b2 := float32(f.B0), float32(f.B1), float32(f.B2)
+	a1, a2 := float32(f.A1), float32(f.A2)
+
+	for ch := 0; ch < out.Channels; ch++ {
+		s := &f.state32[ch]
+		for fr := 0; fr < out.Frames; fr++ {
+			x0 := f.in32.At(ch, fr)
+			y0 := b0*x0 + b1*s.x1 + b2*s.x2 - a1*s.y1 - a2*s.y2
+			s.x2, s.x1 = s.x1, x0
+			s.y2, s.y1 = s.y1, y0
+			out.Set(ch, fr, y0)
+		}
+	}
+	return nil
+}
+
+func (f *Biquad) Process64(out *Buffer64) error {
+	if f.state64 == nil {
+		f.state64 = make([]biquadState64, out.Channels)
+	}
+
+	for ch := 0; ch < out.Channels; ch++ {
+		s := &f.state64[ch]
+		for fr := 0; fr < out.Frames; fr++ {
+			x0 := f.in64.At(ch, fr)
+			y0 := f.B0*x0 + f.B1*s.x1 + f.B2*s.x2 - f.A1*s.y1 - f.A2*s.y2
+			s.x2, s.x1 = s.x1, x0
+			s.y2, s.y1 = s.y1, y0
+			out.Set(ch, fr, y0)
+		}
+	}
+	return nil
+}
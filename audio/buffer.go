@@ -0,0 +1,137 @@
+package audio
+
+// Buffer32 holds one block of multi-channel audio as 32-bit floats,
+// either interleaved (Data, frame-major: ch0,ch1,...,ch0,ch1,...) or
+// planar (Planes, one contiguous slice per channel). Nodes read and
+// write through At/Set so they don't need to care which layout a given
+// buffer uses.
+type Buffer32 struct {
+	Channels int
+	Frames   int
+	Planar   bool
+	Data     []float32   // valid when !Planar, len == Channels*Frames
+	Planes   [][]float32 // valid when Planar, len(Planes) == Channels, each len == Frames
+}
+
+// NewBuffer32 allocates a zeroed buffer for channels channels of frames
+// samples each.
+func NewBuffer32(channels, frames int, planar bool) *Buffer32 {
+	b := &Buffer32{Channels: channels, Frames: frames, Planar: planar}
+	if planar {
+		b.Planes = make([][]float32, channels)
+		for i := range b.Planes {
+			b.Planes[i] = make([]float32, frames)
+		}
+	} else {
+		b.Data = make([]float32, channels*frames)
+	}
+	return b
+}
+
+func (b *Buffer32) At(ch, frame int) float32 {
+	if b.Planar {
+		return b.Planes[ch][frame]
+	}
+	return b.Data[frame*b.Channels+ch]
+}
+
+func (b *Buffer32) Set(ch, frame int, v float32) {
+	if b.Planar {
+		b.Planes[ch][frame] = v
+	} else {
+		b.Data[frame*b.Channels+ch] = v
+	}
+}
+
+// Channel returns channel ch as a contiguous slice. For planar buffers
+// this is a direct view; for interleaved buffers it's a copy, since the
+// samples aren't contiguous in memory.
+func (b *Buffer32) Channel(ch int) []float32 {
+	if b.Planar {
+		return b.Planes[ch]
+	}
+	out := make([]float32, b.Frames)
+	for i := range out {
+		out[i] = b.Data[i*b.Channels+ch]
+	}
+	return out
+}
+
+// Zero clears the buffer to silence.
+func (b *Buffer32) Zero() {
+	if b.Planar {
+		for _, p := range b.Planes {
+			for i := range p {
+				p[i] = 0
+			}
+		}
+		return
+	}
+	for i := range b.Data {
+		b.Data[i] = 0
+	}
+}
+
+// Buffer64 is Buffer32's 64-bit-wide counterpart, for nodes that want
+// the extra precision (e.g. long delay lines, IIR filter state) at the
+// cost of twice the memory traffic.
+type Buffer64 struct {
+	Channels int
+	Frames   int
+	Planar   bool
+	Data     []float64
+	Planes   [][]float64
+}
+
+func NewBuffer64(channels, frames int, planar bool) *Buffer64 {
+	b := &Buffer64{Channels: channels, Frames: frames, Planar: planar}
+	if planar {
+		b.Planes = make([][]float64, channels)
+		for i := range b.Planes {
+			b.Planes[i] = make([]float64, frames)
+		}
+	} else {
+		b.Data = make([]float64, channels*frames)
+	}
+	return b
+}
+
+func (b *Buffer64) At(ch, frame int) float64 {
+	if b.Planar {
+		return b.Planes[ch][frame]
+	}
+	return b.Data[frame*b.Channels+ch]
+}
+
+func (b *Buffer64) Set(ch, frame int, v float64) {
+	if b.Planar {
+		b.Planes[ch][frame] = v
+	} else {
+		b.Data[frame*b.Channels+ch] = v
+	}
+}
+
+func (b *Buffer64) Channel(ch int) []float64 {
+	if b.Planar {
+		return b.Planes[ch]
+	}
+	out := make([]float64, b.Frames)
+	for i := range out {
+		out[i] = b.Data[i*b.Channels+ch]
+	}
+	return out
+}
+
+func (b *Buffer64) Zero() {
+	if b.Planar {
+		for _, p := range b.Planes {
+			for i := range p {
+				p[i] = 0
+			}
+		}
+		return
+	}
+	for i := range b.Data {
+		b.Data[i] = 0
+	}
+}
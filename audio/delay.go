@@ -0,0 +1,87 @@
+package audio
+
+// Delay delays its single input by a fixed number of samples, per
+// channel, carrying state across blocks in an internal ring buffer.
+type Delay struct {
+	in32 *Buffer32
+	in64 *Buffer64
+
+	samples int
+	ring32  [][]float32 // per channel, length samples
+	ring64  [][]float64
+	pos     int
+}
+
+// NewDelay returns a NodeFactory for a Delay holding samples of history
+// per channel.
+func NewDelay(samples int) NodeFactory {
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node {
+		return &Delay{in32: in32[0], in64: in64[0], samples: samples}
+	}
+}
+
+func (d *Delay) Process32(out *Buffer32) error {
+	if d.samples == 0 {
+		for ch := 0; ch < out.Channels; ch++ {
+			for fr := 0; fr < out.Frames; fr++ {
+				out.Set(ch, fr, d.in32.At(ch, fr))
+			}
+		}
+		return nil
+	}
+
+	if d.ring32 == nil {
+		d.ring32 = make([][]float32, out.Channels)
+		for i := range d.ring32 {
+			d.ring32[i] = make([]float32, d.samples)
+		}
+	}
+
+	for ch := 0; ch < out.Channels; ch++ {
+		ring := d.ring32[ch]
+		p := d.pos
+		for fr := 0; fr < out.Frames; fr++ {
+			out.Set(ch, fr, ring[p])
+			ring[p] = d.in32.At(ch, fr)
+			p++
+			if p == len(ring) {
+				p = 0
+			}
+		}
+	}
+	d.pos = (d.pos + out.Frames) % d.samples
+	return nil
+}
+
+func (d *Delay) Process64(out *Buffer64) error {
+	if d.samples == 0 {
+		for ch := 0; ch < out.Channels; ch++ {
+			for fr := 0; fr < out.Frames; fr++ {
+				out.Set(ch, fr, d.in64.At(ch, fr))
+			}
+		}
+		return nil
+	}
+
+	if d.ring64 == nil {
+		d.ring64 = make([][]float64, out.Channels)
+		for i := range d.ring64 {
+			d.ring64[i] = make([]float64, d.samples)
+		}
+	}
+
+	for ch := 0; ch < out.Channels; ch++ {
+		ring := d.ring64[ch]
+		p := d.pos
+		for fr := 0; fr < out.Frames; fr++ {
+			out.Set(ch, fr, ring[p])
+			ring[p] = d.in64.At(ch, fr)
+			p++
+			if p == len(ring) {
+				p = 0
+			}
+		}
+	}
+	d.pos = (d.pos + out.Frames) % d.samples
+	return nil
+}
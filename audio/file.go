@@ -0,0 +1,291 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/egonelbre/exp/codec"
+)
+
+// Codec converts between raw interleaved 16-bit PCM and a container's
+// byte encoding.
+type Codec interface {
+	Encode(w io.Writer, pcm []int16, channels, sampleRate int) error
+	Decode(r io.Reader) (pcm []int16, channels, sampleRate int, err error)
+}
+
+// WAV is a 16-bit PCM RIFF/WAVE codec.
+type WAV struct{}
+
+func (WAV) Encode(w io.Writer, pcm []int16, channels, sampleRate int) error {
+	dataSize := len(pcm) * 2
+	byteRate := sampleRate * channels * 2
+	blockAlign := channels * 2
+
+	return writeChunk(w, "RIFF", 4+24+8+dataSize, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "WAVE"); err != nil {
+			return err
+		}
+		if err := writeChunk(w, "fmt ", 16, func(w io.Writer) error {
+			return write(w,
+				uint16(1), // PCM
+				uint16(channels),
+				uint32(sampleRate),
+				uint32(byteRate),
+				uint16(blockAlign),
+				uint16(16), // bits per sample
+			)
+		}); err != nil {
+			return err
+		}
+		return writeChunk(w, "data", dataSize, func(w io.Writer) error {
+			return write(w, pcm)
+		})
+	})
+}
+
+func (WAV) Decode(r io.Reader) (pcm []int16, channels, sampleRate int, err error) {
+	var riffID, wave [4]byte
+	var riffSize uint32
+	if err = read(r, riffID[:], &riffSize, wave[:]); err != nil {
+		return
+	}
+	if string(riffID[:]) != "RIFF" || string(wave[:]) != "WAVE" {
+		return nil, 0, 0, fmt.Errorf("audio: not a RIFF/WAVE file")
+	}
+
+	var bitsPerSample uint16
+	for {
+		var id [4]byte
+		var size uint32
+		if err = read(r, id[:], &size); err != nil {
+			if err == io.EOF {
+				err = fmt.Errorf("audio: WAVE file has no data chunk")
+			}
+			return
+		}
+
+		switch string(id[:]) {
+		case "fmt ":
+			var format, ch, bits, blockAlign uint16
+			var rate, byteRate uint32
+			if err = read(r, &format, &ch, &rate, &byteRate, &blockAlign, &bits); err != nil {
+				return
+			}
+			channels, sampleRate, bitsPerSample = int(ch), int(rate), bits
+			if size > 16 {
+				err = skip(r, int64(size-16))
+			}
+		case "data":
+			if bitsPerSample != 16 {
+				err = fmt.Errorf("audio: only 16-bit PCM WAV is supported, got %d-bit", bitsPerSample)
+				return
+			}
+			pcm = make([]int16, size/2)
+			if err = read(r, pcm); err != nil {
+				return
+			}
+			if size%2 == 1 {
+				err = skip(r, 1)
+			}
+			return
+		default:
+			err = skip(r, int64(size))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// CompressedWAV wraps WAV's container in a codec.Codec (the
+// raw/flate/zstd post-encoders package codec provides for physics snapshots,
+// reused here instead of hand-rolling another flate wrapper). It is
+// not a FLAC-bitstream implementation — a real FLAC codec needs linear
+// prediction and rice coding to hit FLAC's ratios — but it gives
+// FileSource/FileSink a "compressed" path to exercise end to end until
+// a real FLAC codec lands. The zero value compresses with codec.Raw.
+type CompressedWAV struct {
+	Codec codec.Codec
+}
+
+func (c CompressedWAV) codec() codec.Codec {
+	if c.Codec == nil {
+		return codec.Raw{}
+	}
+	return c.Codec
+}
+
+func (c CompressedWAV) Encode(w io.Writer, pcm []int16, channels, sampleRate int) error {
+	var buf bytes.Buffer
+	if err := (WAV{}).Encode(&buf, pcm, channels, sampleRate); err != nil {
+		return err
+	}
+	_, err := w.Write(c.codec().Compress(buf.Bytes()))
+	return err
+}
+
+func (c CompressedWAV) Decode(r io.Reader) (pcm []int16, channels, sampleRate int, err error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return (WAV{}).Decode(bytes.NewReader(c.codec().Decompress(raw)))
+}
+
+func write(w io.Writer, vs ...interface{}) error {
+	for _, v := range vs {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func read(r io.Reader, vs ...interface{}) error {
+	for _, v := range vs {
+		if err := binary.Read(r, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skip(r io.Reader, n int64) error {
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+func writeChunk(w io.Writer, id string, size int, body func(io.Writer) error) error {
+	if len(id) != 4 {
+		return fmt.Errorf("audio: chunk id must be 4 bytes, got %q", id)
+	}
+	if _, err := io.WriteString(w, id); err != nil {
+		return err
+	}
+	if err := write(w, uint32(size)); err != nil {
+		return err
+	}
+	return body(w)
+}
+
+// FileSource is a source Node: it decodes pcm up front with a Codec and
+// emits it one block at a time, returning io.EOF once it runs dry (in
+// the same call that delivers the last, zero-padded partial block).
+type FileSource struct {
+	pcm      []int16
+	channels int
+	pos      int // in frames
+}
+
+// NewFileSource decodes r with codec and returns a NodeFactory that
+// streams it block by block, plus the file's sample rate.
+func NewFileSource(r io.Reader, codec Codec) (factory NodeFactory, sampleRate int, err error) {
+	pcm, channels, sampleRate, err := codec.Decode(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	src := &FileSource{pcm: pcm, channels: channels}
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node { return src }, sampleRate, nil
+}
+
+func (s *FileSource) frames() int {
+	if s.channels == 0 {
+		return 0
+	}
+	return len(s.pcm) / s.channels
+}
+
+func (s *FileSource) Process32(out *Buffer32) error {
+	total := s.frames()
+	for fr := 0; fr < out.Frames; fr++ {
+		src := s.pos + fr
+		for ch := 0; ch < out.Channels; ch++ {
+			var v float32
+			if src < total && ch < s.channels {
+				v = float32(s.pcm[src*s.channels+ch]) / 32768
+			}
+			out.Set(ch, fr, v)
+		}
+	}
+	s.pos += out.Frames
+	if s.pos >= total {
+		return io.EOF
+	}
+	return nil
+}
+
+func (s *FileSource) Process64(out *Buffer64) error {
+	total := s.frames()
+	for fr := 0; fr < out.Frames; fr++ {
+		src := s.pos + fr
+		for ch := 0; ch < out.Channels; ch++ {
+			var v float64
+			if src < total && ch < s.channels {
+				v = float64(s.pcm[src*s.channels+ch]) / 32768
+			}
+			out.Set(ch, fr, v)
+		}
+	}
+	s.pos += out.Frames
+	if s.pos >= total {
+		return io.EOF
+	}
+	return nil
+}
+
+// FileSink is a sink Node: it accumulates every block of its single
+// input as 16-bit PCM, ready for WriteTo to encode once processing ends.
+type FileSink struct {
+	in32 *Buffer32
+	in64 *Buffer64
+
+	channels int
+	pcm      []int16
+}
+
+// NewFileSink returns a NodeFactory for a FileSink over its (only)
+// input.
+func NewFileSink() NodeFactory {
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node {
+		return &FileSink{in32: in32[0], in64: in64[0]}
+	}
+}
+
+func (s *FileSink) Process32(out *Buffer32) error {
+	s.channels = s.in32.Channels
+	for fr := 0; fr < s.in32.Frames; fr++ {
+		for ch := 0; ch < s.in32.Channels; ch++ {
+			s.pcm = append(s.pcm, floatToPCM16(s.in32.At(ch, fr)))
+		}
+	}
+	return nil
+}
+
+func (s *FileSink) Process64(out *Buffer64) error {
+	s.channels = s.in64.Channels
+	for fr := 0; fr < s.in64.Frames; fr++ {
+		for ch := 0; ch < s.in64.Channels; ch++ {
+			s.pcm = append(s.pcm, floatToPCM16(float32(s.in64.At(ch, fr))))
+		}
+	}
+	return nil
+}
+
+// WriteTo encodes every block accumulated so far with codec.
+func (s *FileSink) WriteTo(w io.Writer, codec Codec, sampleRate int) error {
+	return codec.Encode(w, s.pcm, s.channels, sampleRate)
+}
+
+func floatToPCM16(v float32) int16 {
+	v *= 32767
+	switch {
+	case v > 32767:
+		v = 32767
+	case v < -32768:
+		v = -32768
+	}
+	return int16(v)
+}
@@ -0,0 +1,35 @@
+package audio
+
+// Gain scales its single input by a fixed factor.
+type Gain struct {
+	in32   *Buffer32
+	in64   *Buffer64
+	Factor float64
+}
+
+// NewGain returns a NodeFactory for a Gain node applying factor to its
+// (only) input.
+func NewGain(factor float64) NodeFactory {
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node {
+		return &Gain{in32: in32[0], in64: in64[0], Factor: factor}
+	}
+}
+
+func (g *Gain) Process32(out *Buffer32) error {
+	f := float32(g.Factor)
+	for ch := 0; ch < out.Channels; ch++ {
+		for fr := 0; fr < out.Frames; fr++ {
+			out.Set(ch, fr, g.in32.At(ch, fr)*f)
+		}
+	}
+	return nil
+}
+
+func (g *Gain) Process64(out *Buffer64) error {
+	for ch := 0; ch < out.Channels; ch++ {
+		for fr := 0; fr < out.Frames; fr++ {
+			out.Set(ch, fr, g.in64.At(ch, fr)*g.Factor)
+		}
+	}
+	return nil
+}
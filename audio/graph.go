@@ -0,0 +1,203 @@
+package audio
+
+import "fmt"
+
+// NodeFactory builds a Node once its upstream buffers are known. Graph
+// calls it during Build, in dependency order, so in32/in64 already hold
+// the output buffers of the named deps a node was Add-ed with.
+type NodeFactory func(in32 []*Buffer32, in64 []*Buffer64) Node
+
+// entry is one node registered with a Graph: its name (for wiring by
+// name), how to build it, and the buffers Graph allocated for its
+// output once it exists.
+type entry struct {
+	name  string
+	deps  []string
+	build NodeFactory
+
+	node  Node
+	out32 *Buffer32
+	out64 *Buffer64
+}
+
+// Graph wires Nodes into a fixed processing order, allocates their
+// buffers, and drives them block by block at a fixed sample rate and
+// block size. A node never sees the graph directly: it's built with
+// direct references to the exact upstream buffers it depends on, so
+// Process32/Process64 only ever touch plain buffers.
+type Graph struct {
+	SampleRate int
+	Channels   int
+	BlockSize  int
+	Planar     bool
+
+	entries []entry
+	order   []int // topological order over entries, computed by Build
+}
+
+// NewGraph creates an empty graph. Call Add for every node, then Build
+// once before Process32/Process64/Run/Tick32.
+func NewGraph(sampleRate, channels, blockSize int) *Graph {
+	return &Graph{SampleRate: sampleRate, Channels: channels, BlockSize: blockSize}
+}
+
+// Add registers a node under name, to be built from the named upstream
+// nodes' buffers (in the order given; that order is what makes input 0
+// vs. input 1 meaningful to a node like Mixer). The node itself isn't
+// built until Build runs, once every dependency's buffers exist.
+func (g *Graph) Add(name string, deps []string, build NodeFactory) {
+	g.entries = append(g.entries, entry{name: name, deps: deps, build: build})
+	g.order = nil
+}
+
+// Output returns the buffers allocated for the named node. Valid after
+// Build.
+func (g *Graph) Output(name string) (*Buffer32, *Buffer64) {
+	e := g.entry(name)
+	return e.out32, e.out64
+}
+
+func (g *Graph) entry(name string) *entry {
+	for i := range g.entries {
+		if g.entries[i].name == name {
+			return &g.entries[i]
+		}
+	}
+	panic(fmt.Sprintf("audio: unknown node %q", name))
+}
+
+func (g *Graph) indexOf(name string) int {
+	for i, e := range g.entries {
+		if e.name == name {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("audio: unknown node %q", name))
+}
+
+// Build computes a processing order where every node comes after its
+// dependencies (a topological sort), then builds each node and
+// allocates its output buffers in that order.
+func (g *Graph) Build() error {
+	order, err := g.topoSort()
+	if err != nil {
+		return err
+	}
+	g.order = order
+
+	for _, i := range order {
+		e := &g.entries[i]
+
+		in32 := make([]*Buffer32, len(e.deps))
+		in64 := make([]*Buffer64, len(e.deps))
+		for d, dep := range e.deps {
+			up := g.entry(dep)
+			in32[d] = up.out32
+			in64[d] = up.out64
+		}
+
+		e.node = e.build(in32, in64)
+		e.out32 = NewBuffer32(g.Channels, g.BlockSize, g.Planar)
+		e.out64 = NewBuffer64(g.Channels, g.BlockSize, g.Planar)
+	}
+	return nil
+}
+
+func (g *Graph) topoSort() ([]int, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(g.entries))
+	order := make([]int, 0, len(g.entries))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("audio: cycle in graph at node %q", g.entries[i].name)
+		}
+		state[i] = visiting
+		for _, dep := range g.entries[i].deps {
+			if err := visit(g.indexOf(dep)); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		order = append(order, i)
+		return nil
+	}
+
+	for i := range g.entries {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Process32 runs one block through every node, in dependency order, in
+// the 32-bit-wide path.
+func (g *Graph) Process32() error {
+	for _, i := range g.order {
+		e := &g.entries[i]
+		if err := e.node.Process32(e.out32); err != nil {
+			return fmt.Errorf("audio: node %q: %w", e.name, err)
+		}
+	}
+	return nil
+}
+
+// Process64 is Process32's 64-bit-wide counterpart.
+func (g *Graph) Process64() error {
+	for _, i := range g.order {
+		e := &g.entries[i]
+		if err := e.node.Process64(e.out64); err != nil {
+			return fmt.Errorf("audio: node %q: %w", e.name, err)
+		}
+	}
+	return nil
+}
+
+// Run drives the graph in pull mode for blocks blocks, stopping early
+// if a node returns an error (io.EOF from a source is the common case).
+func (g *Graph) Run(blocks int, use64 bool) error {
+	for b := 0; b < blocks; b++ {
+		var err error
+		if use64 {
+			err = g.Process64()
+		} else {
+			err = g.Process32()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Callback32 is a push-mode callback: a real-time audio host calls it
+// once per hardware block and the graph hands back its sink's output.
+type Callback32 func(buf *Buffer32) error
+
+// Tick32 runs one block and hands sinkName's output to cb, the push-mode
+// analogue of a pull-based Process32 followed by reading
+// Output(sinkName). Real-time hosts drive the graph through this call
+// from their audio thread's own callback.
+func (g *Graph) Tick32(sinkName string, cb Callback32) error {
+	if err := g.Process32(); err != nil {
+		return err
+	}
+	return cb(g.entry(sinkName).out32)
+}
+
+// Tick64 is Tick32's 64-bit-wide counterpart.
+func (g *Graph) Tick64(sinkName string, cb func(*Buffer64) error) error {
+	if err := g.Process64(); err != nil {
+		return err
+	}
+	return cb(g.entry(sinkName).out64)
+}
@@ -0,0 +1,50 @@
+package audio
+
+// Mixer sums all its inputs into one output, each weighted by Gains
+// (an input past the end of Gains is mixed at unity).
+type Mixer struct {
+	in32  []*Buffer32
+	in64  []*Buffer64
+	Gains []float64
+}
+
+// NewMixer returns a NodeFactory for a Mixer with the given per-input
+// gains, applied in the order the inputs were listed in Graph.Add.
+func NewMixer(gains ...float64) NodeFactory {
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node {
+		return &Mixer{in32: in32, in64: in64, Gains: gains}
+	}
+}
+
+func (m *Mixer) gain(i int) float64 {
+	if i < len(m.Gains) {
+		return m.Gains[i]
+	}
+	return 1
+}
+
+func (m *Mixer) Process32(out *Buffer32) error {
+	out.Zero()
+	for i, in := range m.in32 {
+		g := float32(m.gain(i))
+		for ch := 0; ch < out.Channels; ch++ {
+			for fr := 0; fr < out.Frames; fr++ {
+				out.Set(ch, fr, out.At(ch, fr)+in.At(ch, fr)*g)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Mixer) Process64(out *Buffer64) error {
+	out.Zero()
+	for i, in := range m.in64 {
+		g := m.gain(i)
+		for ch := 0; ch < out.Channels; ch++ {
+			for fr := 0; fr < out.Frames; fr++ {
+				out.Set(ch, fr, out.At(ch, fr)+in.At(ch, fr)*g)
+			}
+		}
+	}
+	return nil
+}
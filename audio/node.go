@@ -1,14 +1,22 @@
+// Package audio implements a small block-based audio graph: nodes that
+// read/write Buffer32 or Buffer64, wired together and driven by a Graph.
 package audio
 
+// Node processes one block of audio at a time, in either precision. A
+// node reads whatever inputs it was wired to at construction time and
+// writes its result into the buffer passed to Process32/Process64, which
+// Graph allocates as that node's output.
 type Node interface {
 	Node32
 	Node64
 }
 
+// Node32 is the 32-bit-wide half of Node, for graphs run with Process32.
 type Node32 interface {
-	Process32(*Buffer32) error
+	Process32(out *Buffer32) error
 }
 
+// Node64 is the 64-bit-wide half of Node, for graphs run with Process64.
 type Node64 interface {
-	Process64(*Buffer64) error
+	Process64(out *Buffer64) error
 }
\ No newline at end of file
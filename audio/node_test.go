@@ -0,0 +1,236 @@
+package audio
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// ramp32 is a source Node emitting a ramp (0, 1, 2, ...) that rises by
+// one per frame, with every channel of a frame holding the same value,
+// so tests can check a node's actual arithmetic instead of driving it
+// with silence. It only implements the 32-bit path.
+type ramp32 struct{ n float32 }
+
+func (r *ramp32) Process32(out *Buffer32) error {
+	for fr := 0; fr < out.Frames; fr++ {
+		for ch := 0; ch < out.Channels; ch++ {
+			out.Set(ch, fr, r.n)
+		}
+		r.n++
+	}
+	return nil
+}
+func (r *ramp32) Process64(out *Buffer64) error { panic("unused") }
+
+func addRamp32(g *Graph, name string) {
+	g.Add(name, nil, func(in32 []*Buffer32, in64 []*Buffer64) Node { return &ramp32{} })
+}
+
+func TestGain32(t *testing.T) {
+	g := NewGraph(48000, 1, 4)
+	addRamp32(g, "src")
+	g.Add("gain", []string{"src"}, NewGain(0.5))
+	if err := g.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Process32(); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := g.Output("gain")
+	for fr := 0; fr < out.Frames; fr++ {
+		want := float32(fr) * 0.5
+		if got := out.At(0, fr); got != want {
+			t.Fatalf("frame %d: got %v, want %v", fr, got, want)
+		}
+	}
+}
+
+func TestMixer32(t *testing.T) {
+	g := NewGraph(48000, 1, 4)
+	addRamp32(g, "a")
+	addRamp32(g, "b")
+	g.Add("mix", []string{"a", "b"}, NewMixer(1, 2))
+	if err := g.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Process32(); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := g.Output("mix")
+	for fr := 0; fr < out.Frames; fr++ {
+		want := float32(fr)*1 + float32(fr)*2
+		if got := out.At(0, fr); got != want {
+			t.Fatalf("frame %d: got %v, want %v", fr, got, want)
+		}
+	}
+}
+
+func TestDelay32(t *testing.T) {
+	const samples = 3
+	g := NewGraph(48000, 1, 4)
+	addRamp32(g, "src")
+	g.Add("delay", []string{"src"}, NewDelay(samples))
+	if err := g.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []float32
+	for block := 0; block < 3; block++ {
+		if err := g.Process32(); err != nil {
+			t.Fatal(err)
+		}
+		out, _ := g.Output("delay")
+		for fr := 0; fr < out.Frames; fr++ {
+			got = append(got, out.At(0, fr))
+		}
+	}
+
+	// the first `samples` outputs are the zeroed ring buffer, then the
+	// ramp reappears delayed by `samples` frames.
+	for i, v := range got {
+		var want float32
+		if i >= samples {
+			want = float32(i - samples)
+		}
+		if v != want {
+			t.Fatalf("output %d: got %v, want %v", i, v, want)
+		}
+	}
+}
+
+func TestBiquadLowpassAttenuatesHighFrequency(t *testing.T) {
+	const sampleRate = 48000.0
+	const blockSize = 512
+
+	g := NewGraph(sampleRate, 1, blockSize)
+	g.Add("src", nil, func(in32 []*Buffer32, in64 []*Buffer64) Node { return &sineSource{freq: 8000, rate: sampleRate} })
+	g.Add("lpf", []string{"src"}, NewLowpassBiquad(sampleRate, 500, 0.707))
+	if err := g.Build(); err != nil {
+		t.Fatal(err)
+	}
+	// let the filter settle past its transient response.
+	for i := 0; i < 4; i++ {
+		if err := g.Process32(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in, _ := g.Output("src")
+	out, _ := g.Output("lpf")
+	if rms(out) >= rms(in) {
+		t.Fatalf("lowpass at 500Hz did not attenuate an 8kHz tone: in rms %v, out rms %v", rms(in), rms(out))
+	}
+}
+
+type sineSource struct {
+	freq, rate float64
+	phase      float64
+}
+
+func (s *sineSource) Process32(out *Buffer32) error {
+	for fr := 0; fr < out.Frames; fr++ {
+		v := float32(math.Sin(s.phase))
+		for ch := 0; ch < out.Channels; ch++ {
+			out.Set(ch, fr, v)
+		}
+		s.phase += 2 * math.Pi * s.freq / s.rate
+	}
+	return nil
+}
+func (s *sineSource) Process64(out *Buffer64) error { panic("unused") }
+
+func rms(b *Buffer32) float64 {
+	var sum float64
+	for ch := 0; ch < b.Channels; ch++ {
+		for fr := 0; fr < b.Frames; fr++ {
+			v := float64(b.At(ch, fr))
+			sum += v * v
+		}
+	}
+	return math.Sqrt(sum / float64(b.Channels*b.Frames))
+}
+
+func TestResamplerUpsample(t *testing.T) {
+	g := NewGraph(48000, 1, 8)
+	addRamp32(g, "src")
+	g.Add("resample", []string{"src"}, NewResampler(0.5))
+	if err := g.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Process32(); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := g.Output("resample")
+	for fr := 0; fr < out.Frames; fr++ {
+		want := float32(fr) * 0.5
+		if got := out.At(0, fr); got != want {
+			t.Fatalf("frame %d: got %v, want %v", fr, got, want)
+		}
+	}
+}
+
+func TestResamplerRejectsDownsampling(t *testing.T) {
+	g := NewGraph(48000, 1, 8)
+	addRamp32(g, "src")
+	g.Add("resample", []string{"src"}, NewResampler(2.0))
+	if err := g.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Process32(); err == nil {
+		t.Fatal("Process32 with Ratio > 1: got nil error, want an error")
+	}
+}
+
+func TestWAVRoundtrip(t *testing.T) {
+	pcm := []int16{1, -1, 1000, -1000, 32767, -32768, 0, 12345}
+	var buf bytes.Buffer
+	if err := (WAV{}).Encode(&buf, pcm, 2, 44100); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	gotPCM, channels, sampleRate, err := (WAV{}).Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if channels != 2 || sampleRate != 44100 {
+		t.Fatalf("Decode header = (channels=%d, sampleRate=%d), want (2, 44100)", channels, sampleRate)
+	}
+	if len(gotPCM) != len(pcm) {
+		t.Fatalf("Decode: got %d samples, want %d", len(gotPCM), len(pcm))
+	}
+	for i := range pcm {
+		if gotPCM[i] != pcm[i] {
+			t.Fatalf("sample %d: got %d, want %d", i, gotPCM[i], pcm[i])
+		}
+	}
+}
+
+func TestCompressedWAVRoundtrip(t *testing.T) {
+	pcm := make([]int16, 2000)
+	for i := range pcm {
+		pcm[i] = int16(i % 100)
+	}
+
+	var buf bytes.Buffer
+	enc := CompressedWAV{}
+	if err := enc.Encode(&buf, pcm, 1, 48000); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	gotPCM, channels, sampleRate, err := enc.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if channels != 1 || sampleRate != 48000 {
+		t.Fatalf("Decode header = (channels=%d, sampleRate=%d), want (1, 48000)", channels, sampleRate)
+	}
+	if len(gotPCM) != len(pcm) {
+		t.Fatalf("Decode: got %d samples, want %d", len(gotPCM), len(pcm))
+	}
+	for i := range pcm {
+		if gotPCM[i] != pcm[i] {
+			t.Fatalf("sample %d: got %d, want %d", i, gotPCM[i], pcm[i])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+// Resampler linearly interpolates its single input by Ratio =
+// srcRate/dstRate (Ratio > 1 downsamples, < 1 upsamples), carrying
+// fractional phase across blocks so a non-integer ratio doesn't drift.
+//
+// It assumes consecutive blocks from its input are contiguous in the
+// source stream and that Ratio keeps each output block's source span
+// within one input block. Graph hands every node equal-length input and
+// output buffers, so producing one BlockSize-frame output block needs
+// Ratio*BlockSize source frames to already be sitting in a BlockSize-frame
+// input buffer; that only holds for Ratio <= 1 (unity or upsampling).
+// Downsampling (Ratio > 1) needs Graph to hand this node a wider input
+// span than its output, which it doesn't support yet, so Process32/64
+// reject it rather than silently running off the end of the input block.
+type Resampler struct {
+	in32  *Buffer32
+	in64  *Buffer64
+	Ratio float64
+
+	phase float64
+}
+
+// NewResampler returns a NodeFactory for a Resampler converting its
+// input by ratio. ratio must be <= 1 until Graph can hand this node a
+// wider input span than its output; see the Resampler doc comment.
+func NewResampler(ratio float64) NodeFactory {
+	return func(in32 []*Buffer32, in64 []*Buffer64) Node {
+		return &Resampler{in32: in32[0], in64: in64[0], Ratio: ratio}
+	}
+}
+
+func (r *Resampler) Process32(out *Buffer32) error {
+	if r.Ratio > 1 {
+		return fmt.Errorf("audio: Resampler ratio %v downsamples, which needs more source frames per block than Graph provides (see Resampler doc comment)", r.Ratio)
+	}
+	n := r.in32.Frames
+	phase := r.phase
+	for fr := 0; fr < out.Frames; fr++ {
+		i0, frac := splitPhase(phase, n)
+		i1 := i0 + 1
+		if i1 >= n {
+			i1 = i0
+		}
+		for ch := 0; ch < out.Channels; ch++ {
+			s0 := r.in32.At(ch, i0)
+			s1 := r.in32.At(ch, i1)
+			out.Set(ch, fr, s0+(s1-s0)*float32(frac))
+		}
+		phase += r.Ratio
+	}
+	r.phase = math.Mod(phase, float64(n))
+	return nil
+}
+
+func (r *Resampler) Process64(out *Buffer64) error {
+	if r.Ratio > 1 {
+		return fmt.Errorf("audio: Resampler ratio %v downsamples, which needs more source frames per block than Graph provides (see Resampler doc comment)", r.Ratio)
+	}
+	n := r.in64.Frames
+	phase := r.phase
+	for fr := 0; fr < out.Frames; fr++ {
+		i0, frac := splitPhase(phase, n)
+		i1 := i0 + 1
+		if i1 >= n {
+			i1 = i0
+		}
+		for ch := 0; ch < out.Channels; ch++ {
+			s0 := r.in64.At(ch, i0)
+			s1 := r.in64.At(ch, i1)
+			out.Set(ch, fr, s0+(s1-s0)*frac)
+		}
+		phase += r.Ratio
+	}
+	r.phase = math.Mod(phase, float64(n))
+	return nil
+}
+
+// splitPhase turns a continuous source position into an integer frame
+// index clamped to [0, n-1] and the fractional part between it and the
+// next frame.
+func splitPhase(phase float64, n int) (i int, frac float64) {
+	i = int(phase)
+	frac = phase - float64(i)
+	if i >= n-1 {
+		if n == 0 {
+			return 0, 0
+		}
+		return n - 1, 0
+	}
+	return i, frac
+}
@@ -2,54 +2,85 @@ package bit
 
 import "io"
 
+// bufSize is how many bytes Writer/Reader stage before touching the
+// underlying io.Writer/io.Reader. Flushing/refilling in bulk instead of
+// one byte at a time is what makes the 64-bit-wide paths pay off.
+const bufSize = 4096
+
 type Writer struct {
 	w     io.Writer
+	buf   []byte
 	bits  uint64
 	nbits uint
 	err   error
 }
 
-func NewWriter(w io.Writer) *Writer { return &Writer{w, 0, 0, nil} }
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, buf: make([]byte, 0, bufSize)}
+}
 
-// flushpartial flushes all the remaining half bytes
+// flush drains whole bytes out of the accumulator into buf, draining buf
+// to the underlying writer once it's full.
 func (w *Writer) flush() {
 	if w.err != nil {
 		w.nbits = 0
 		return
 	}
 
-	var buf [16]byte
-	n := 0
-	for w.nbits > 8 {
-		buf[n] = byte(w.bits)
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.bits))
 		w.bits >>= 8
 		w.nbits -= 8
-		n++
 	}
 
-	_, w.err = w.w.Write(buf[0:n])
+	if len(w.buf) >= bufSize {
+		w.drain()
+	}
 }
 
-// flushpartial flushes all the remaining half bytes
-func (w *Writer) flushpartial() {
-	w.flush()
-	if w.err != nil {
-		w.nbits = 0
+// drain writes out whatever is staged in buf.
+func (w *Writer) drain() {
+	if len(w.buf) == 0 {
 		return
 	}
+	if w.err == nil {
+		_, w.err = w.w.Write(w.buf)
+	}
+	w.buf = w.buf[:0]
+}
 
-	if w.nbits > 0 {
-		_, w.err = w.w.Write([]byte{byte(w.bits)})
+// flushpartial flushes all the remaining bits, padding the last byte
+// with zeros, and drains buf to the underlying writer.
+func (w *Writer) flushpartial() {
+	w.flush()
+	if w.err == nil && w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.bits))
 		w.bits = 0
 		w.nbits = 0
 	}
+	w.drain()
 }
 
-// WriteBits width lowest bits from x to the underlying writer
+// WriteBits writes the lowest width bits from x to the underlying
+// writer, LSB-first.
 func (w *Writer) WriteBits(x, width uint) error {
-	w.bits |= uint64(x) << w.nbits
+	return w.WriteBits64(uint64(x), width)
+}
+
+// WriteBits64 writes the lowest width bits (width up to 57) from x to
+// the underlying writer, LSB-first. The wider width lets range/arithmetic
+// coders push a whole renormalized state out in one call instead of
+// bit-by-bit.
+func (w *Writer) WriteBits64(x uint64, width uint) error {
+	if w.nbits+width > 64 {
+		w.flush()
+	}
+
+	mask := uint64(1)<<width - 1
+	w.bits |= (x & mask) << w.nbits
 	w.nbits += width
-	if w.nbits > 16 {
+
+	if w.nbits > 56 {
 		w.flush()
 	}
 	return w.err
@@ -60,6 +91,19 @@ func (w *Writer) WriteBit(x int) error {
 	return w.WriteBits(uint(x&1), 1)
 }
 
+// WriteBitsMSB writes the lowest width bits from x to the underlying
+// writer, most-significant-bit first, the order range coders renormalize
+// in. It trades the bulk throughput of WriteBits64 for that order, so
+// prefer it only where the bit order actually matters.
+func (w *Writer) WriteBitsMSB(x uint64, width uint) error {
+	for i := width; i > 0; i-- {
+		if err := w.WriteBit(int(x >> (i - 1))); err != nil {
+			return err
+		}
+	}
+	return w.err
+}
+
 // Align aligns the writer to the next byte
 func (w *Writer) Align() error {
 	w.flushpartial()
@@ -70,66 +114,115 @@ func (w *Writer) Close() error { return w.Align() }
 
 type Reader struct {
 	r     io.Reader
-	bits  uint
+	buf   []byte
+	pos   int
+	bits  uint64
 	nbits uint
 	err   error
 }
 
 func NewReader(r io.Reader) *Reader {
-	return &Reader{r, 0, 8, nil}
+	return &Reader{r: r, buf: make([]byte, 0, bufSize)}
 }
 
-// read reads a single byte from the underlying reader
-func (r *Reader) read() {
-	if r.err != nil {
-		r.nbits = 8
+// refill tops up buf from the underlying reader once it's been
+// fully consumed.
+func (r *Reader) refill() {
+	if r.pos < len(r.buf) {
 		return
 	}
+	n, err := r.r.Read(r.buf[:cap(r.buf)])
+	r.buf = r.buf[:n]
+	r.pos = 0
+	if n == 0 && err != nil {
+		r.err = err
+	}
+}
+
+// fill ensures at least need bits sit in the accumulator, moving up to 8
+// bytes at a time into it from buf and refilling buf from the underlying
+// reader whenever it runs empty.
+func (r *Reader) fill(need uint) {
+	for r.nbits < need && r.err == nil {
+		r.refill()
+		if r.pos >= len(r.buf) {
+			if r.err == nil {
+				r.err = io.EOF
+			}
+			return
+		}
 
-	var temp [1]byte
-	_, r.err = r.r.Read(temp[:])
-	r.bits = uint(temp[0])
+		n := len(r.buf) - r.pos
+		if n > 8 {
+			n = 8
+		}
+		if room := int(64-r.nbits) / 8; n > room {
+			n = room
+		}
+		if n == 0 {
+			return
+		}
+
+		var chunk uint64
+		for i := 0; i < n; i++ {
+			chunk |= uint64(r.buf[r.pos+i]) << uint(8*i)
+		}
+		r.bits |= chunk << r.nbits
+		r.nbits += uint(n) * 8
+		r.pos += n
+	}
 }
 
-// Align aligns the reader to the next byte so that the next ReadBits will start
-// reading a new byte from the underlying reader
+// Align aligns the reader to the next byte so that the next ReadBits will
+// start reading a new byte from the underlying reader
 func (r *Reader) Align() {
-	r.nbits = 8
+	drop := r.nbits % 8
+	r.bits >>= drop
+	r.nbits -= drop
 }
 
 // ReadBits reads width bits from the underlying reader
 // width must be less than 32
 func (r *Reader) ReadBits(width uint) (uint, error) {
-	if r.err != nil {
-		return 0, r.err
-	}
+	x, err := r.ReadBits64(width)
+	return uint(x), err
+}
 
-	left := 8 - int(r.nbits)
-	if left > int(width) {
-		mask := uint((1 << width) - 1)
-		x := r.bits >> r.nbits
-		r.nbits += width
-		return x & mask, nil
+// ReadBits64 reads width bits (width up to 57) from the underlying
+// reader, LSB-first, refilling its accumulator 8 bytes at a time from a
+// bufSize-byte read of the underlying reader instead of one byte per call.
+func (r *Reader) ReadBits64(width uint) (uint64, error) {
+	x, err := r.PeekBits(width)
+	if err != nil {
+		return 0, err
 	}
+	r.ConsumeBits(width)
+	return x, nil
+}
 
-	n := 8 - r.nbits
-	x := r.bits >> r.nbits
-	for int(width)-int(n) > 0 {
-		r.read()
-		r.nbits -= 8
+// PeekBits returns the next width bits without consuming them, so a
+// range coder can inspect upcoming bits before deciding how many of them
+// it actually used.
+func (r *Reader) PeekBits(width uint) (uint64, error) {
+	r.fill(width)
+	if r.nbits < width {
 		if r.err != nil {
 			return 0, r.err
 		}
-		x |= r.bits << n
-		n += 8
+		return 0, io.ErrUnexpectedEOF
 	}
-	r.nbits += width
-	mask := uint(1<<width - 1)
-	return x & mask, nil
+	mask := uint64(1)<<width - 1
+	return r.bits & mask, nil
+}
+
+// ConsumeBits advances past width bits previously returned by PeekBits.
+func (r *Reader) ConsumeBits(width uint) {
+	r.bits >>= width
+	r.nbits -= width
 }
 
 // ReadBit reads a single bit from the underlying reader
 func (r *Reader) ReadBit() (int, error) {
-	x, err := r.ReadBits(1)
+	x, err := r.ReadBits64(1)
 	return int(x), err
 }
@@ -0,0 +1,175 @@
+package bit
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// snapshotWidths mimics the bit widths the physics snapshot encoder
+// spends per cube: a handful of short delta fields plus one wider one.
+var snapshotWidths = [8]uint{1, 1, 2, 5, 5, 5, 9, 18}
+
+const snapshotCubes = 901
+
+func BenchmarkWriterWriteBits64(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := NewWriter(io.Discard)
+		for c := 0; c < snapshotCubes; c++ {
+			for _, width := range snapshotWidths {
+				w.WriteBits64(uint64(c)&(uint64(1)<<width-1), width)
+			}
+		}
+		w.Close()
+	}
+}
+
+// TestWriteReadBits64 round-trips a mix of bit widths, mirroring the
+// per-cube fields the physics snapshot encoder packs.
+func TestWriteReadBits64(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	values := make([]uint64, 0, snapshotCubes*len(snapshotWidths))
+	widths := make([]uint, 0, cap(values))
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for c := 0; c < snapshotCubes; c++ {
+		for _, width := range snapshotWidths {
+			v := r.Uint64() & (uint64(1)<<width - 1)
+			values = append(values, v)
+			widths = append(widths, width)
+			if err := w.WriteBits64(v, width); err != nil {
+				t.Fatalf("WriteBits64: %v", err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rd := NewReader(&buf)
+	for i, width := range widths {
+		got, err := rd.ReadBits64(width)
+		if err != nil {
+			t.Fatalf("ReadBits64(%d): %v", i, err)
+		}
+		if got != values[i] {
+			t.Fatalf("ReadBits64(%d) width %d = %d, want %d", i, width, got, values[i])
+		}
+	}
+}
+
+// TestWriteBits64WideWidth exercises the full 57-bit width WriteBits64
+// supports in one call.
+func TestWriteBits64WideWidth(t *testing.T) {
+	const width = 57
+	want := uint64(1)<<width - 1
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.WriteBits64(want, width); err != nil {
+		t.Fatalf("WriteBits64: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r := NewReader(&buf)
+	got, err := r.ReadBits64(width)
+	if err != nil {
+		t.Fatalf("ReadBits64: %v", err)
+	}
+	if got != want {
+		t.Fatalf("ReadBits64 = %#x, want %#x", got, want)
+	}
+}
+
+// TestPeekBitsConsumeBits checks that PeekBits doesn't advance the
+// reader and that a subsequent ConsumeBits does, the split range coders
+// rely on to inspect upcoming bits before committing to them.
+func TestPeekBitsConsumeBits(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteBits64(0x2a, 6)
+	w.WriteBits64(0x3, 2)
+	w.Close()
+
+	r := NewReader(&buf)
+	peeked, err := r.PeekBits(6)
+	if err != nil {
+		t.Fatalf("PeekBits: %v", err)
+	}
+	if peeked != 0x2a {
+		t.Fatalf("PeekBits = %#x, want %#x", peeked, 0x2a)
+	}
+	if peeked2, err := r.PeekBits(6); err != nil || peeked2 != peeked {
+		t.Fatalf("second PeekBits = %#x, %v; want %#x, nil", peeked2, err, peeked)
+	}
+	r.ConsumeBits(6)
+
+	rest, err := r.ReadBits64(2)
+	if err != nil {
+		t.Fatalf("ReadBits64: %v", err)
+	}
+	if rest != 0x3 {
+		t.Fatalf("ReadBits64 after ConsumeBits = %#x, want %#x", rest, 0x3)
+	}
+}
+
+// TestWriteBitsMSB checks the MSB-first order against WriteBits64's
+// LSB-first order using the same bit pattern.
+func TestWriteBitsMSB(t *testing.T) {
+	const width = 5
+	const pattern = 0x13 // 10011, not a palindrome, so the two orders differ
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.WriteBitsMSB(pattern, width); err != nil {
+		t.Fatalf("WriteBitsMSB: %v", err)
+	}
+	w.Close()
+
+	r := NewReader(&buf)
+	got, err := r.ReadBits64(width)
+	if err != nil {
+		t.Fatalf("ReadBits64: %v", err)
+	}
+	// WriteBitsMSB writes bit (width-1) down to 0 in that order, so a
+	// LSB-first reader sees the pattern bit-reversed.
+	var want uint64
+	for i := uint(0); i < width; i++ {
+		if pattern&(1<<i) != 0 {
+			want |= 1 << (width - 1 - i)
+		}
+	}
+	if got != want {
+		t.Fatalf("ReadBits64 after WriteBitsMSB = %#b, want %#b", got, want)
+	}
+}
+
+func BenchmarkReaderReadBits64(b *testing.B) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for c := 0; c < snapshotCubes; c++ {
+		for _, width := range snapshotWidths {
+			w.WriteBits64(uint64(c)&(uint64(1)<<width-1), width)
+		}
+	}
+	w.Close()
+	encoded := buf.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(bytes.NewReader(encoded))
+		for c := 0; c < snapshotCubes; c++ {
+			for _, width := range snapshotWidths {
+				if _, err := r.ReadBits64(width); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package codec provides pluggable post-encoders that run as a final pass
+// over already entropy-coded data. The delta streams produced by the
+// arithmetic coder still contain redundancy across frames (similar runs of
+// unchanged cubes, repeated small deltas), and a general-purpose compressor
+// can squeeze out what the coder's per-symbol model misses.
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec compresses and decompresses an encoded snapshot. Implementations
+// must round-trip: Decompress(Compress(b)) must equal b.
+type Codec interface {
+	Compress(data []byte) []byte
+	Decompress(data []byte) []byte
+}
+
+// Raw passes data through unchanged. Useful as a baseline to compare
+// the other codecs against.
+type Raw struct{}
+
+func (Raw) Compress(data []byte) []byte   { return data }
+func (Raw) Decompress(data []byte) []byte { return data }
+
+// Flate wraps compress/flate. Level follows the flate.BestSpeed..
+// flate.BestCompression range.
+type Flate struct {
+	Level int
+}
+
+func (f Flate) Compress(data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, f.Level)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func (f Flate) Decompress(data []byte) []byte {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Zstd wraps github.com/klauspost/compress/zstd. Dict, when non-empty, is a
+// dictionary trained from a sample of past snapshots via TrainDict; zstd's
+// dictionary mode is built exactly for this shape of workload, many small
+// similar messages, which is what per-frame snapshots are. Dict must be a
+// trained dictionary blob, not arbitrary bytes: zstd validates it against
+// its own dictionary format and rejects anything else.
+//
+// A Zstd lazily builds its *zstd.Encoder/*zstd.Decoder on first use and
+// reuses them for every later call instead of paying setup/teardown per
+// call: that cost would otherwise be baked into every encode/decode
+// timing sample a caller takes. Use New or &Zstd{...} (not a bare
+// Zstd{...} value) so the cached encoder/decoder survive across calls.
+type Zstd struct {
+	Level zstd.EncoderLevel
+	Dict  []byte
+
+	encOnce sync.Once
+	enc     *zstd.Encoder
+	decOnce sync.Once
+	dec     *zstd.Decoder
+}
+
+func (z *Zstd) encoder() *zstd.Encoder {
+	z.encOnce.Do(func() {
+		opts := []zstd.EOption{zstd.WithEncoderLevel(z.Level)}
+		if len(z.Dict) > 0 {
+			opts = append(opts, zstd.WithEncoderDict(z.Dict))
+		}
+		enc, err := zstd.NewWriter(nil, opts...)
+		if err != nil {
+			panic(err)
+		}
+		z.enc = enc
+	})
+	return z.enc
+}
+
+func (z *Zstd) decoder() *zstd.Decoder {
+	z.decOnce.Do(func() {
+		var opts []zstd.DOption
+		if len(z.Dict) > 0 {
+			opts = append(opts, zstd.WithDecoderDicts(z.Dict))
+		}
+		dec, err := zstd.NewReader(nil, opts...)
+		if err != nil {
+			panic(err)
+		}
+		z.dec = dec
+	})
+	return z.dec
+}
+
+func (z *Zstd) Compress(data []byte) []byte {
+	return z.encoder().EncodeAll(data, make([]byte, 0, len(data)))
+}
+
+func (z *Zstd) Decompress(data []byte) []byte {
+	out, err := z.decoder().DecodeAll(data, nil)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// New returns the codec named by kind ("raw", "flate" or "zstd"). level
+// covers fastest (1) through best-ratio (4); flate maps it onto its own
+// 1-9 scale. dict is only used by zstd.
+func New(kind string, level int, dict []byte) Codec {
+	switch kind {
+	case "flate":
+		return Flate{Level: flateLevel(level)}
+	case "zstd":
+		return &Zstd{Level: zstdLevel(level), Dict: dict}
+	default:
+		return Raw{}
+	}
+}
+
+func flateLevel(level int) int {
+	switch {
+	case level <= 1:
+		return flate.BestSpeed
+	case level >= 4:
+		return flate.BestCompression
+	default:
+		return flate.DefaultCompression
+	}
+}
+
+func zstdLevel(level int) zstd.EncoderLevel {
+	switch {
+	case level <= 1:
+		return zstd.SpeedFastest
+	case level == 2:
+		return zstd.SpeedDefault
+	case level == 3:
+		return zstd.SpeedBetterCompression
+	default:
+		return zstd.SpeedBestCompression
+	}
+}
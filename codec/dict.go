@@ -0,0 +1,17 @@
+package codec
+
+import "github.com/klauspost/compress/dict"
+
+// TrainDict builds a zstd dictionary from samples (e.g. a window of past
+// snapshots) for use as Zstd.Dict. zstd's dictionary mode is built
+// exactly for this shape of workload: many small, structurally similar
+// messages, which is what per-frame snapshots are. maxSize caps the
+// dictionary's size in bytes.
+//
+// The result is only valid as a zstd dictionary: it's built with
+// klauspost/compress's own training format (magic number plus entropy
+// tables), not arbitrary bytes, so don't hand Zstd.Dict a raw sample
+// instead of one built by this function.
+func TrainDict(samples [][]byte, maxSize int) ([]byte, error) {
+	return dict.BuildZstdDict(samples, dict.Options{MaxDictSize: maxSize, HashBytes: 6})
+}
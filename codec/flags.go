@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+)
+
+// flagKind and flagLevel are the single declaration of the -codec/
+// -codec-level flags. Both physicscompress/main and physics/encoding.go
+// want a command-line-selected codec, so the wiring lives here once
+// instead of being pasted into each of them: two flag.String("codec",
+// ...) calls on flag.CommandLine would panic with "flag redefined" the
+// moment both packages end up linked into the same binary.
+var (
+	flagKind  = flag.String("codec", "flate", "post-encode codec: raw, flate or zstd")
+	flagLevel = flag.Int("codec-level", 1, "compression level passed to the codec, 1 (fastest) .. 4 (best ratio)")
+)
+
+var (
+	cacheMu    sync.Mutex
+	cacheKey   string
+	cacheCodec Codec
+)
+
+// FromFlags returns the codec selected by -codec/-codec-level. dict is
+// only used by zstd.
+//
+// The flags don't change after parsing (and dict, in practice, is set
+// once before the first call), so FromFlags builds the Codec once and
+// caches it rather than calling New on every Encode/Decode: a Zstd's
+// own cached encoder/decoder (see codec.go) only pays off if the Zstd
+// value itself survives across calls, and activeCodec in both main.go
+// and physics/encoding.go calls FromFlags once per frame.
+func FromFlags(dict []byte) Codec {
+	key := fmt.Sprintf("%s|%d|%x", *flagKind, *flagLevel, dict)
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cacheKey != key {
+		cacheCodec = New(*flagKind, *flagLevel, dict)
+		cacheKey = key
+	}
+	return cacheCodec
+}
+
+// Kind and Level report the flags' current values, e.g. for a summary
+// line that echoes which codec produced it.
+func Kind() string { return *flagKind }
+func Level() int   { return *flagLevel }
@@ -0,0 +1,17 @@
+package codec
+
+import "testing"
+
+func TestFromFlagsCaches(t *testing.T) {
+	// zstd is built as a *Zstd, so a == b below only holds for the same
+	// cached instance, not for two equal values as with the default flate.
+	old := *flagKind
+	*flagKind = "zstd"
+	defer func() { *flagKind = old }()
+
+	a := FromFlags(nil)
+	b := FromFlags(nil)
+	if a != b {
+		t.Fatalf("FromFlags(nil) returned distinct Codec values across calls, want the same cached instance")
+	}
+}
@@ -0,0 +1,124 @@
+// Package bench factors the compare-codecs-on-one-capture loop out of
+// physicscompress's main so it can be reused for any set of
+// (name, encode, decode) candidates, not just a single fixed encoder.
+package bench
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/montanaflynn/stats"
+)
+
+// Case is one encoder/decoder under comparison for a single frame. Both
+// closures capture whatever state (ordering, baseline, current deltas)
+// the caller already has on hand for that frame; bench only measures
+// and checks their output.
+type Case struct {
+	Name   string
+	Encode func() []byte
+	Decode func(snapshot []byte) (mirror []byte)
+}
+
+// Tracker accumulates one Case's measurements across many frames. Create
+// one per candidate and feed it every frame via Observe.
+type Tracker struct {
+	Name       string
+	Sizes      []float64 // kb per frame
+	Speeds     []float64 // kbps per frame, size*60
+	EncodeUs   []float64
+	DecodeUs   []float64
+	Mismatches int
+	Frames     int
+}
+
+func NewTracker(name string) *Tracker { return &Tracker{Name: name} }
+
+// Observe runs c once, measuring its encode/decode cost and checking
+// the round trip against want (typically the serialized current frame).
+func (t *Tracker) Observe(c Case, want []byte) {
+	start := time.Now()
+	snapshot := c.Encode()
+	encodeUs := time.Since(start).Seconds() * 1e6
+
+	start = time.Now()
+	mirror := c.Decode(snapshot)
+	decodeUs := time.Since(start).Seconds() * 1e6
+
+	size := float64(len(snapshot)*8) / 1000.0
+
+	t.Sizes = append(t.Sizes, size)
+	t.Speeds = append(t.Speeds, size*60.0)
+	t.EncodeUs = append(t.EncodeUs, encodeUs)
+	t.DecodeUs = append(t.DecodeUs, decodeUs)
+	if !equalBytes(mirror, want) {
+		t.Mismatches++
+	}
+	t.Frames++
+}
+
+func equalBytes(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Row is one printable line of a comparison table: a Tracker's stats
+// reduced down to the headline numbers worth comparing across codecs.
+type Row struct {
+	Name        string
+	BitsPerCube float64
+	KbpsP50     float64
+	KbpsP90     float64
+	EncodeUsP50 float64
+	DecodeUsP50 float64
+	Mismatches  int
+	Frames      int
+}
+
+// Summarize reduces a Tracker's per-frame samples to a Row. cubesPerFrame
+// is the number of cubes in a frame (N in the physics snapshot).
+func Summarize(t *Tracker, cubesPerFrame int) Row {
+	mean := must(stats.Mean(t.Sizes))
+	return Row{
+		Name:        t.Name,
+		BitsPerCube: mean * 1000 / float64(cubesPerFrame),
+		KbpsP50:     must(stats.Percentile(t.Speeds, 50)),
+		KbpsP90:     must(stats.Percentile(t.Speeds, 90)),
+		EncodeUsP50: must(stats.Percentile(t.EncodeUs, 50)),
+		DecodeUsP50: must(stats.Percentile(t.DecodeUs, 50)),
+		Mismatches:  t.Mismatches,
+		Frames:      t.Frames,
+	}
+}
+
+// must panics on err. Summarize only passes stats in-range percentiles,
+// so an empty series (a Tracker that never saw an Observe call) is what
+// makes Mean or Percentile return an error here.
+func must(v float64, err error) float64 {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// PrintTable prints one line per Row: bits/cube, kbps percentiles,
+// encode/decode µs percentiles, and a round-trip check.
+func PrintTable(rows []Row) {
+	fmt.Printf("%-16s %10s %10s %10s %10s %10s %12s\n",
+		"CODEC", "bits/cube", "p50 kbps", "p90 kbps", "p50 enc µs", "p50 dec µs", "roundtrip")
+	for _, r := range rows {
+		roundtrip := "ok"
+		if r.Mismatches > 0 {
+			roundtrip = fmt.Sprintf("%d/%d bad", r.Mismatches, r.Frames)
+		}
+		fmt.Printf("%-16s %10.3f %10.3f %10.3f %10.3f %10.3f %12s\n",
+			r.Name, r.BitsPerCube, r.KbpsP50, r.KbpsP90, r.EncodeUsP50, r.DecodeUsP50, roundtrip)
+	}
+}
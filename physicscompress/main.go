@@ -39,6 +39,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"flag"
 	"fmt"
@@ -48,6 +49,8 @@ import (
 	"runtime"
 	"sort"
 
+	"github.com/egonelbre/exp/codec"
+	"github.com/egonelbre/exp/physicscompress/bench"
 	"github.com/egonelbre/exp/qpc"
 
 	"github.com/montanaflynn/stats"
@@ -57,6 +60,16 @@ const dontpack = false
 const dontsort = false
 const debugsnap = true
 
+var flagBench = flag.Bool("bench", false, "compare codecs on delta_data.bin instead of running a single pass")
+
+// activeCodec runs as a final pass over the bit-packed snapshot, using
+// the codec selected by the -codec/-codec-level flags (declared once in
+// the codec package, since physics.activeCodec wants the same flags for
+// the arithmetic-coded path).
+func activeCodec() codec.Codec {
+	return codec.FromFlags(nil)
+}
+
 func check(err error) {
 	if err != nil {
 		panic(err)
@@ -74,7 +87,9 @@ type Deltas []Delta
 
 var previous_nochange []bool
 
-func Encode(order *Ordering, baseline, current Deltas) (snapshot []byte) {
+// encodeRaw runs the bit-packed encoder without any post-encode codec,
+// so benchmarks can apply different codecs to the same coded bytes.
+func encodeRaw(order *Ordering, baseline, current Deltas) (snapshot []byte) {
 	wr := NewWriter()
 
 	nochange := make([]bool, len(baseline))
@@ -92,9 +107,14 @@ func Encode(order *Ordering, baseline, current Deltas) (snapshot []byte) {
 	return wr.Bytes()
 }
 
-// ignores reading errors
-func Decode(order *Ordering, baseline, current Deltas, snapshot []byte) {
-	rd := NewReader(snapshot)
+func Encode(order *Ordering, baseline, current Deltas) (snapshot []byte) {
+	return activeCodec().Compress(encodeRaw(order, baseline, current))
+}
+
+// decodeRaw is the counterpart to encodeRaw: it expects raw, un-codec'd
+// bytes from the bit-packed encoder.
+func decodeRaw(order *Ordering, baseline, current Deltas, raw []byte) {
+	rd := NewReader(raw)
 
 	nochange := make([]bool, len(baseline))
 	rd.ReadBools(nochange)
@@ -107,6 +127,11 @@ func Decode(order *Ordering, baseline, current Deltas, snapshot []byte) {
 	rd.ReadIndexed(nochange, order.XYZ, baseline, current)
 }
 
+// ignores reading errors
+func Decode(order *Ordering, baseline, current Deltas, snapshot []byte) {
+	decodeRaw(order, baseline, current, activeCodec().Decompress(snapshot))
+}
+
 func ReadDelta(r io.Reader, current Deltas) error {
 	for i := range current {
 		if err := current[i].ReadFrom(r); err != nil {
@@ -116,6 +141,103 @@ func ReadDelta(r io.Reader, current Deltas) error {
 	return nil
 }
 
+func serializeDeltas(d Deltas) []byte {
+	var buf bytes.Buffer
+	for i := range d {
+		check(d[i].WriteTo(&buf))
+	}
+	return buf.Bytes()
+}
+
+// benchCodecs are the candidates the -bench comparison runs: the same
+// arithmetic-free bit-packed encoder wrapped in each codec in turn. The
+// "raw" row is that bit-packed output with no post-encode pass; the
+// serialized deltas themselves are not among the candidates.
+//
+// This intentionally leaves out physics.State.Encode/Decode (the
+// arithmetic-coded path): encodeRaw/decodeRaw here only know how to
+// drive the bit-packed Writer/Reader in this package, and physics.State
+// itself (the type physics/encoding.go's Encode/Decode methods hang
+// off, constructed and advanced frame-by-frame outside this file) isn't
+// part of this tree, so there's no constructor to build one from the
+// same history window runBenchmark already tracks. Once that type lands
+// here, add an "arithmetic" (and "arithmetic+<codec>") row alongside
+// these by feeding it the same historic/baseline/current Cubes used
+// below.
+var benchCodecs = []struct {
+	name  string
+	codec codec.Codec
+}{
+	{"raw", codec.New("raw", 0, nil)},
+	{"flate", codec.New("flate", 1, nil)},
+	{"zstd-fast", codec.New("zstd", 1, nil)},
+	{"zstd-default", codec.New("zstd", 2, nil)},
+	{"zstd-better", codec.New("zstd", 3, nil)},
+	{"zstd-best", codec.New("zstd", 4, nil)},
+}
+
+// runBenchmark replays delta_data.bin once, running every codec
+// candidate on each frame, and prints a comparison table instead of the
+// single-pass summary main normally prints.
+func runBenchmark(buffer *bufio.Reader) {
+	const N = 901
+	baseline := make(Deltas, N)
+	order := NewOrdering(baseline)
+
+	var history [8]Deltas
+	for i := range history {
+		history[i] = make(Deltas, N)
+	}
+
+	frame := 0
+	for i := 0; i < 6; i += 1 {
+		check(ReadDelta(buffer, history[frame]))
+		frame += 1
+	}
+
+	trackers := make([]*bench.Tracker, len(benchCodecs))
+	for i, c := range benchCodecs {
+		trackers[i] = bench.NewTracker(c.name)
+	}
+
+	mirror := make(Deltas, N)
+	for {
+		historic := history[(frame-7+len(history))%len(history)]
+		baseline := history[(frame-6+len(history))%len(history)]
+		current := history[frame%len(history)]
+
+		err := ReadDelta(buffer, current)
+		if err == io.EOF {
+			break
+		}
+		check(err)
+		frame += 1
+
+		order.Improve(historic, baseline)
+		want := serializeDeltas(current)
+
+		for i, c := range benchCodecs {
+			cod := c.codec
+			trackers[i].Observe(bench.Case{
+				Name: c.name,
+				Encode: func() []byte {
+					return cod.Compress(encodeRaw(order, baseline, current))
+				},
+				Decode: func(snapshot []byte) []byte {
+					decodeRaw(order, baseline, mirror, cod.Decompress(snapshot))
+					return serializeDeltas(mirror)
+				},
+			}, want)
+		}
+	}
+
+	rows := make([]bench.Row, len(trackers))
+	for i, t := range trackers {
+		rows[i] = bench.Summarize(t, N)
+	}
+	bench.PrintTable(rows)
+}
+
 func main() {
 	verbose := flag.Bool("v", false, "verbose output")
 	flag.Parse()
@@ -126,6 +248,11 @@ func main() {
 
 	buffer := bufio.NewReader(file)
 
+	if *flagBench {
+		runBenchmark(buffer)
+		return
+	}
+
 	sizes := make([]float64, 0)
 	speeds := make([]float64, 0)
 
@@ -206,7 +333,7 @@ func main() {
 	}
 
 	fmt.Println()
-	fmt.Printf("#%d %.3fkbps ±%.3fkbps\n", len(sizes), stats.Mean(speeds), stats.StdDevS(speeds))
+	fmt.Printf("#%d %.3fkbps ±%.3fkbps  (codec=%s level=%d)\n", len(sizes), stats.Mean(speeds), stats.StdDevS(speeds), codec.Kind(), codec.Level())
 	fmt.Println()
 
 	fmt.Printf("MIN %10.3f kbps\n", stats.Min(speeds))
@@ -222,7 +349,7 @@ func main() {
 	fmt.Printf("  AVG  %10.3f bits per cube\n", stats.Mean(sizes)*1000/float64(N))
 
 	fmt.Println()
-	fmt.Println("TIMING:")
+	fmt.Printf("TIMING:  codec=%s level=%d\n", codec.Kind(), codec.Level())
 	qpc.PrintSummary(improve, encode, decode)
 }
 
@@ -1,14 +1,26 @@
 package physics
 
 import (
-	"flag"
 	"fmt"
 
 	"github.com/egonelbre/exp/bit"
 	"github.com/egonelbre/exp/coder/arith"
+	"github.com/egonelbre/exp/codec"
 )
 
-var flagFlate = flag.Bool("flate", true, "use flate compression")
+var flagCodecDict []byte
+
+// activeCodec runs as a final pass over the arithmetic-coded bytes,
+// using the codec selected by the -codec/-codec-level flags (declared
+// once in the codec package, since physicscompress/main wants the same
+// flags for its own, non-arithmetic path).
+func activeCodec() codec.Codec {
+	return codec.FromFlags(flagCodecDict)
+}
+
+// SetCodecDict installs a zstd dictionary trained from a sample of past
+// snapshots. It has no effect unless -codec=zstd.
+func SetCodecDict(dict []byte) { flagCodecDict = dict }
 
 func encode32(v int32) uint64 { return uint64(bit.ZEncode(int64(v))) }
 func decode32(v uint64) int32 { return int32(bit.ZDecode(v)) }
@@ -144,11 +156,11 @@ func (s *State) Encode() []byte {
 		}
 	*/
 	enc.Close()
-	return enc.Bytes()
+	return activeCodec().Compress(enc.Bytes())
 }
 
 func (s *State) Decode(snapshot []byte) {
-	dec := arith.NewDecoder(snapshot)
+	dec := arith.NewDecoder(activeCodec().Decompress(snapshot))
 
 	s.Current().Assign(s.Baseline())
 	baseline := s.Baseline().Cubes
@@ -0,0 +1,270 @@
+package physics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/egonelbre/exp/codec"
+)
+
+// Deltas is a full per-frame snapshot of cubes.
+type Deltas = []Cube
+
+// cubeFields is the number of int32 fields making up a Cube, in the
+// order marshalCubes/unmarshalCubes read and write them.
+const cubeFields = 8
+
+// FrameKind identifies how WriteFrame encoded a record, so ReadFrame
+// knows whether (and against what) to apply a delta.
+type FrameKind byte
+
+const (
+	KeyFrame FrameKind = iota // self-contained, decodes without a reference frame
+	PFrame                    // delta against the immediately preceding frame
+	BFrame                    // delta against a lagging baseline frame
+)
+
+func (k FrameKind) String() string {
+	switch k {
+	case KeyFrame:
+		return "key"
+	case PFrame:
+		return "p"
+	case BFrame:
+		return "b"
+	default:
+		return fmt.Sprintf("kind(%d)", byte(k))
+	}
+}
+
+// SessionConfig controls keyframe cadence, how far back a B-frame's
+// baseline lags, and the codec that compresses each record's payload.
+type SessionConfig struct {
+	KeyframeEvery int // emit a keyframe every N frames; <=0 means only frame 0
+	BaselineLag   int // frames back a B-frame references; <=0 disables B-frames, falling back to P-frames
+	Codec         codec.Codec
+}
+
+// DefaultSessionConfig matches the baseline=frame-6 cadence the
+// per-frame benchmark used before Session existed.
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		KeyframeEvery: 300,
+		BaselineLag:   6,
+		Codec:         codec.Flate{Level: 1},
+	}
+}
+
+// Session turns a sequence of per-frame cube snapshots into a stream of
+// (kind, payload) records: periodic keyframes, P-frames delta'd against
+// the previous frame, and B-frames delta'd against a lagging baseline.
+// It keeps the rolling window of history needed to produce and resolve
+// those records internally, so a lossy channel can resync from the next
+// keyframe instead of requiring a full replay from frame zero.
+//
+// A Session is one-directional: use one for WriteFrame and a separate
+// one, fed only by ReadFrame, to mirror it on the receiving end.
+type Session struct {
+	cfg     SessionConfig
+	history []Deltas // ring buffer, indexed by frame % len(history)
+	frame   int
+	cubes   int // cube count of the last frame seen; 0 until the first
+}
+
+// NewSession creates a Session with an empty history window sized to
+// hold cfg.BaselineLag frames plus the current one.
+func NewSession(cfg SessionConfig) *Session {
+	window := cfg.BaselineLag + 1
+	if window < 1 {
+		window = 1
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = codec.Raw{}
+	}
+	return &Session{cfg: cfg, history: make([]Deltas, window)}
+}
+
+func (s *Session) slot(frame int) int {
+	n := len(s.history)
+	return ((frame % n) + n) % n
+}
+
+// WriteFrame encodes cur against the session's rolling history and
+// writes one record to w: a 1-byte kind, a 4-byte little-endian
+// reference frame number (0 for keyframes), a 4-byte little-endian
+// payload length, then the compressed payload.
+func (s *Session) WriteFrame(w io.Writer, cur Deltas) error {
+	kind, ref, payload := s.encode(cur)
+
+	if err := binary.Write(w, binary.LittleEndian, kind); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, int32(ref)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(payload))); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+
+	s.remember(cur)
+	return nil
+}
+
+// ReadFrame reads one record written by WriteFrame and reconstructs the
+// frame it describes, resolving P/B-frames against this session's own
+// rolling history. It returns io.EOF once r is exhausted between
+// records.
+func (s *Session) ReadFrame(r io.Reader) (Deltas, error) {
+	var kind FrameKind
+	if err := binary.Read(r, binary.LittleEndian, &kind); err != nil {
+		return nil, err
+	}
+	var ref int32
+	if err := binary.Read(r, binary.LittleEndian, &ref); err != nil {
+		return nil, err
+	}
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	raw := s.cfg.Codec.Decompress(payload)
+
+	var cur Deltas
+	switch FrameKind(kind) {
+	case KeyFrame:
+		if len(raw)%(cubeFields*4) != 0 {
+			return nil, fmt.Errorf("physics: truncated key-frame %d payload: %d bytes is not a whole number of cubes", s.frame, len(raw))
+		}
+		if n := len(raw) / (cubeFields * 4); s.cubes != 0 && n != s.cubes {
+			return nil, fmt.Errorf("physics: truncated key-frame %d payload: got %d cubes, want %d", s.frame, n, s.cubes)
+		}
+		cur = unmarshalCubes(raw)
+	case PFrame, BFrame:
+		base := s.history[s.slot(int(ref))]
+		if base == nil {
+			return nil, fmt.Errorf("physics: session has no frame %d to decode %s-frame %d against", ref, FrameKind(kind), s.frame)
+		}
+		if want := len(base) * cubeFields * 4; len(raw) < want {
+			return nil, fmt.Errorf("physics: truncated %s-frame %d payload: got %d bytes, want %d", FrameKind(kind), s.frame, len(raw), want)
+		}
+		cur = applyDeltaCubes(base, raw)
+	default:
+		return nil, fmt.Errorf("physics: unknown frame kind %d", kind)
+	}
+
+	s.remember(cur)
+	return cur, nil
+}
+
+func (s *Session) remember(cur Deltas) {
+	cp := make(Deltas, len(cur))
+	copy(cp, cur)
+	s.history[s.slot(s.frame)] = cp
+	s.cubes = len(cur)
+	s.frame++
+}
+
+func (s *Session) encode(cur Deltas) (kind FrameKind, ref int, payload []byte) {
+	needKey := s.frame == 0 || (s.cfg.KeyframeEvery > 0 && s.frame%s.cfg.KeyframeEvery == 0)
+	switch {
+	case needKey:
+		return KeyFrame, 0, s.cfg.Codec.Compress(marshalCubes(cur))
+
+	case s.cfg.BaselineLag > 0 && s.frame >= s.cfg.BaselineLag && s.history[s.slot(s.frame-s.cfg.BaselineLag)] != nil:
+		ref = s.frame - s.cfg.BaselineLag
+		return BFrame, ref, s.cfg.Codec.Compress(deltaCubes(s.history[s.slot(ref)], cur))
+
+	default:
+		ref = s.frame - 1
+		return PFrame, ref, s.cfg.Codec.Compress(deltaCubes(s.history[s.slot(ref)], cur))
+	}
+}
+
+// marshalCubes writes every field of every cube, little-endian, with no
+// delta applied; it's the payload of a keyframe.
+func marshalCubes(cubes Deltas) []byte {
+	buf := make([]byte, 0, len(cubes)*cubeFields*4)
+	for i := range cubes {
+		buf = appendCube(buf, &cubes[i])
+	}
+	return buf
+}
+
+func unmarshalCubes(raw []byte) Deltas {
+	cubes := make(Deltas, len(raw)/(cubeFields*4))
+	for i := range cubes {
+		readCube(raw[i*cubeFields*4:], &cubes[i])
+	}
+	return cubes
+}
+
+// deltaCubes XORs cur against base field by field, the same relation
+// State.Encode uses to find the bits worth spending on each cube.
+func deltaCubes(base, cur Deltas) []byte {
+	buf := make([]byte, 0, len(cur)*cubeFields*4)
+	for i := range cur {
+		d := Cube{
+			Largest:     cur[i].Largest ^ base[i].Largest,
+			A:           cur[i].A ^ base[i].A,
+			B:           cur[i].B ^ base[i].B,
+			C:           cur[i].C ^ base[i].C,
+			X:           cur[i].X ^ base[i].X,
+			Y:           cur[i].Y ^ base[i].Y,
+			Z:           cur[i].Z ^ base[i].Z,
+			Interacting: cur[i].Interacting ^ base[i].Interacting,
+		}
+		buf = appendCube(buf, &d)
+	}
+	return buf
+}
+
+func applyDeltaCubes(base Deltas, raw []byte) Deltas {
+	cur := make(Deltas, len(base))
+	for i := range cur {
+		var d Cube
+		readCube(raw[i*cubeFields*4:], &d)
+		cur[i] = Cube{
+			Largest:     base[i].Largest ^ d.Largest,
+			A:           base[i].A ^ d.A,
+			B:           base[i].B ^ d.B,
+			C:           base[i].C ^ d.C,
+			X:           base[i].X ^ d.X,
+			Y:           base[i].Y ^ d.Y,
+			Z:           base[i].Z ^ d.Z,
+			Interacting: base[i].Interacting ^ d.Interacting,
+		}
+	}
+	return cur
+}
+
+func appendCube(buf []byte, c *Cube) []byte {
+	var tmp [cubeFields * 4]byte
+	binary.LittleEndian.PutUint32(tmp[0:], uint32(c.Largest))
+	binary.LittleEndian.PutUint32(tmp[4:], uint32(c.A))
+	binary.LittleEndian.PutUint32(tmp[8:], uint32(c.B))
+	binary.LittleEndian.PutUint32(tmp[12:], uint32(c.C))
+	binary.LittleEndian.PutUint32(tmp[16:], uint32(c.X))
+	binary.LittleEndian.PutUint32(tmp[20:], uint32(c.Y))
+	binary.LittleEndian.PutUint32(tmp[24:], uint32(c.Z))
+	binary.LittleEndian.PutUint32(tmp[28:], uint32(c.Interacting))
+	return append(buf, tmp[:]...)
+}
+
+func readCube(raw []byte, c *Cube) {
+	c.Largest = int32(binary.LittleEndian.Uint32(raw[0:]))
+	c.A = int32(binary.LittleEndian.Uint32(raw[4:]))
+	c.B = int32(binary.LittleEndian.Uint32(raw[8:]))
+	c.C = int32(binary.LittleEndian.Uint32(raw[12:]))
+	c.X = int32(binary.LittleEndian.Uint32(raw[16:]))
+	c.Y = int32(binary.LittleEndian.Uint32(raw[20:]))
+	c.Z = int32(binary.LittleEndian.Uint32(raw[24:]))
+	c.Interacting = int32(binary.LittleEndian.Uint32(raw[28:]))
+}
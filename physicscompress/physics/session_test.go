@@ -0,0 +1,146 @@
+package physics
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/egonelbre/exp/codec"
+)
+
+func testCubes(n int, seed int32) Deltas {
+	cubes := make(Deltas, n)
+	for i := range cubes {
+		v := seed + int32(i)
+		cubes[i] = Cube{
+			Largest:     v % 3,
+			A:           v,
+			B:           v * 2,
+			C:           v * 3,
+			X:           v * 5,
+			Y:           v * 7,
+			Z:           v * 11,
+			Interacting: v % 2,
+		}
+	}
+	return cubes
+}
+
+func cubesEqual(a, b Deltas) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSessionWriteReadFrame(t *testing.T) {
+	cfg := SessionConfig{KeyframeEvery: 3, BaselineLag: 2, Codec: codec.Flate{Level: 1}}
+
+	var buf bytes.Buffer
+	w := NewSession(cfg)
+	want := make([]Deltas, 8)
+	for i := range want {
+		want[i] = testCubes(16, int32(i*5))
+		if err := w.WriteFrame(&buf, want[i]); err != nil {
+			t.Fatalf("WriteFrame(%d): %v", i, err)
+		}
+	}
+
+	r := NewSession(cfg)
+	for i := range want {
+		got, err := r.ReadFrame(&buf)
+		if err != nil {
+			t.Fatalf("ReadFrame(%d): %v", i, err)
+		}
+		if !cubesEqual(got, want[i]) {
+			t.Fatalf("ReadFrame(%d) = %v, want %v", i, got, want[i])
+		}
+	}
+	if _, err := r.ReadFrame(&buf); err != io.EOF {
+		t.Fatalf("ReadFrame after last frame: got err %v, want io.EOF", err)
+	}
+}
+
+func TestSessionReadFrameTruncatedPayload(t *testing.T) {
+	cfg := SessionConfig{KeyframeEvery: 10, BaselineLag: 2, Codec: codec.Raw{}}
+
+	var buf bytes.Buffer
+	w := NewSession(cfg)
+	first := testCubes(4, 0)
+	if err := w.WriteFrame(&buf, first); err != nil {
+		t.Fatalf("WriteFrame(keyframe): %v", err)
+	}
+
+	r := NewSession(cfg)
+	if _, err := r.ReadFrame(&buf); err != nil {
+		t.Fatalf("ReadFrame(keyframe): %v", err)
+	}
+
+	// Hand-craft a P-frame record whose payload is shorter than the 4
+	// cubes it claims to delta against.
+	var bad bytes.Buffer
+	writeRecordHeader(&bad, PFrame, int32(0), uint32(cubeFields*4)) // length lies: room for 1 cube, not 4
+	bad.Write(make([]byte, cubeFields*4))
+
+	if _, err := r.ReadFrame(&bad); err == nil {
+		t.Fatalf("ReadFrame on truncated payload: got nil error, want an error")
+	}
+}
+
+func TestSessionReadFrameTruncatedKeyFrame(t *testing.T) {
+	cfg := SessionConfig{KeyframeEvery: 10, BaselineLag: 2, Codec: codec.Raw{}}
+
+	r := NewSession(cfg)
+
+	// A 4-cube key-frame payload missing its last few bytes: not even a
+	// whole number of cubes.
+	var notWhole bytes.Buffer
+	full := marshalCubes(testCubes(4, 0))
+	writeRecordHeader(&notWhole, KeyFrame, 0, uint32(len(full)-3))
+	notWhole.Write(full[:len(full)-3])
+	if _, err := r.ReadFrame(&notWhole); err == nil {
+		t.Fatalf("ReadFrame on non-whole-cube key-frame: got nil error, want an error")
+	}
+
+	// Prime the session with a real 4-cube key-frame so it has an
+	// established cube count to check a later short key-frame against.
+	var good bytes.Buffer
+	w := NewSession(cfg)
+	if err := w.WriteFrame(&good, testCubes(4, 0)); err != nil {
+		t.Fatalf("WriteFrame(keyframe): %v", err)
+	}
+	if _, err := r.ReadFrame(&good); err != nil {
+		t.Fatalf("ReadFrame(keyframe): %v", err)
+	}
+
+	// A whole-cube but short key-frame: 1 cube instead of the 4 every
+	// other frame in this session has had.
+	var short bytes.Buffer
+	shortPayload := marshalCubes(testCubes(1, 0))
+	writeRecordHeader(&short, KeyFrame, 0, uint32(len(shortPayload)))
+	short.Write(shortPayload)
+	if _, err := r.ReadFrame(&short); err == nil {
+		t.Fatalf("ReadFrame on short key-frame: got nil error, want an error")
+	}
+}
+
+func writeRecordHeader(buf *bytes.Buffer, kind FrameKind, ref int32, length uint32) {
+	buf.WriteByte(byte(kind))
+	var tmp [4]byte
+	put32(tmp[:], uint32(ref))
+	buf.Write(tmp[:])
+	put32(tmp[:], length)
+	buf.Write(tmp[:])
+}
+
+func put32(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+	b[3] = byte(v >> 24)
+}